@@ -0,0 +1,126 @@
+package ecommercenested
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync"
+
+	"datagen/sink"
+)
+
+// SamplingMode controls how eventPool decides which events to keep once it
+// is at capacity.
+type SamplingMode int
+
+const (
+	// SamplingModeUniform keeps a uniform random sample of everything
+	// produced, evicting the lowest-stamped event when the pool is full.
+	SamplingModeUniform SamplingMode = iota
+	// SamplingModeFIFO disables pooling: events are sent to outCh as soon
+	// as they are generated, same as the original blocking behavior.
+	SamplingModeFIFO
+)
+
+// stampedEvent pairs a generated record with a random stamp. Keeping the
+// highest-stamped events in the pool and evicting the lowest-stamped one on
+// overflow makes the retained set a uniform random sample of everything
+// that was produced, not just whatever arrived last.
+type stampedEvent struct {
+	stamp  float32
+	record sink.SinkRecord
+}
+
+// eventPool is a bounded, fixed-capacity pool of generated events backed by
+// a min-heap on stamp, so Load can keep generating under a slow sink
+// instead of blocking on outCh, while bounding memory to capacity.
+type eventPool struct {
+	mu       sync.Mutex
+	events   []stampedEvent
+	capacity int
+	rng      *rand.Rand
+
+	numSeen    int64
+	numSaved   int64
+	numDropped int64
+}
+
+func newEventPool(capacity int, rng *rand.Rand) *eventPool {
+	if capacity < 1 {
+		// offer() indexes p.events[0] once the pool is "full"; a capacity
+		// below 1 would make it full before ever holding anything.
+		capacity = 1
+	}
+	return &eventPool{capacity: capacity, rng: rng}
+}
+
+func (p *eventPool) Len() int           { return len(p.events) }
+func (p *eventPool) Less(i, j int) bool { return p.events[i].stamp < p.events[j].stamp }
+func (p *eventPool) Swap(i, j int)      { p.events[i], p.events[j] = p.events[j], p.events[i] }
+func (p *eventPool) Push(x interface{}) { p.events = append(p.events, x.(stampedEvent)) }
+func (p *eventPool) Pop() interface{} {
+	old := p.events
+	n := len(old)
+	e := old[n-1]
+	p.events = old[:n-1]
+	return e
+}
+
+// offer adds record to the pool. Once the pool is at capacity, the new
+// event replaces the lowest-stamped one if and only if it draws a higher
+// stamp, otherwise it is dropped.
+func (p *eventPool) offer(record sink.SinkRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.numSeen++
+	e := stampedEvent{stamp: p.rng.Float32(), record: record}
+	if p.Len() < p.capacity {
+		heap.Push(p, e)
+		p.numSaved++
+		return
+	}
+	if e.stamp > p.events[0].stamp {
+		p.events[0] = e
+		heap.Fix(p, 0)
+		// The slot's saved credit transfers from the evicted event to e: it
+		// was never delivered, so count it dropped instead, while e now
+		// holds the one saved credit for the slot. Crediting both would
+		// inflate numSaved+numDropped past numSeen by one per eviction.
+		p.numDropped++
+		return
+	}
+	p.numDropped++
+}
+
+// drain removes and returns every event currently held by the pool.
+func (p *eventPool) drain() []sink.SinkRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := make([]sink.SinkRecord, len(p.events))
+	for i, e := range p.events {
+		records[i] = e.record
+	}
+	p.events = nil
+	return records
+}
+
+// markDelivered reconciles the counters after outCh has (or hasn't) taken a
+// drained record: delivered=false means the record never reached outCh even
+// though it had been counted as saved, so it moves from saved to dropped.
+func (p *eventPool) markDelivered(delivered bool) {
+	if delivered {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.numSaved--
+	p.numDropped++
+}
+
+// stats returns numSeen, numSaved, numDropped for observability.
+func (p *eventPool) stats() (seen, saved, dropped int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.numSeen, p.numSaved, p.numDropped
+}
@@ -0,0 +1,134 @@
+package ecommercenested
+
+import (
+	"context"
+	"datagen/gen"
+	"datagen/sink"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Order is the pure domain representation of an order event.
+type Order struct {
+	Id             int64
+	UserId         int64
+	OrgName        string
+	Amount         float64
+	EventTimestamp string
+}
+
+// OrderEventDTO is the JSON shape an Order is serialized to on the wire.
+type OrderEventDTO struct {
+	Id             int64   `json:"order_id"`
+	UserId         int64   `json:"user_id"`
+	OrgName        string  `json:"org_name"`
+	Amount         float64 `json:"amount"`
+	EventTimestamp string  `json:"event_timestamp"`
+}
+
+func (o Order) toDTO() OrderEventDTO {
+	return OrderEventDTO{
+		Id:             o.Id,
+		UserId:         o.UserId,
+		OrgName:        o.OrgName,
+		Amount:         o.Amount,
+		EventTimestamp: o.EventTimestamp,
+	}
+}
+
+func (d OrderEventDTO) Key() string {
+	return fmt.Sprint(d.Id)
+}
+
+// OrderGenOption configures an orderGen returned by NewOrderGen.
+type OrderGenOption func(*orderGen)
+
+// WithOrderRNG gives the generator its own source of randomness instead of
+// the global math/rand, mirroring UserGenOption's WithRNG.
+func WithOrderRNG(rng *rand.Rand) OrderGenOption {
+	return func(g *orderGen) { g.rng = rng }
+}
+
+// WithOrderClock overrides how the generator reads "now", mirroring
+// UserGenOption's WithClock.
+func WithOrderClock(clock func() time.Time) OrderGenOption {
+	return func(g *orderGen) { g.clock = clock }
+}
+
+type orderGen struct {
+	seqOrderId int64
+	faker      *gofakeit.Faker
+	registry   *UserRegistry
+	rng        *rand.Rand
+	clock      func() time.Time
+}
+
+func NewOrderGen(registry *UserRegistry, opts ...OrderGenOption) *orderGen {
+	g := &orderGen{
+		registry: registry,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	// g.faker must be built here, after opts have had a chance to replace
+	// g.rng via WithOrderRNG: an earlier version of this constructor never
+	// assigned g.faker at all, so the first getOrder() call after a user
+	// was registered panicked on a nil *gofakeit.Faker.
+	g.faker = gen.NewFaker(g.rng)
+	return g
+}
+
+// getOrder samples a real, previously-generated user id/org from the shared
+// registry so that orders reference users that actually exist in the
+// stream. If no user has been generated yet, the order is skipped.
+func (g *orderGen) getOrder() (Order, bool) {
+	userId, orgName, ok := g.registry.SampleExisting()
+	if !ok {
+		return Order{}, false
+	}
+
+	g.seqOrderId++
+	return Order{
+		Id:             g.seqOrderId,
+		UserId:         userId,
+		OrgName:        orgName,
+		Amount:         g.faker.Price(1, 1000),
+		EventTimestamp: g.clock().Format(gen.RwTimestampNaiveLayout),
+	}, true
+}
+
+func (g *orderGen) generate() []sink.SinkRecord {
+	var records []sink.SinkRecord
+	for i := 0; i < 100; i++ {
+		if order, ok := g.getOrder(); ok {
+			records = append(records, sink.Envelope{DTO: order.toDTO(), Topic: "order_events"})
+		}
+	}
+	return records
+}
+
+func (g *orderGen) Load(ctx context.Context, outCh chan<- sink.SinkRecord) {
+	for {
+		records := g.generate()
+		for _, record := range records {
+			select {
+			case <-ctx.Done():
+				return
+			case outCh <- record:
+			}
+		}
+		if len(records) == 0 {
+			// no users registered yet, avoid busy-looping
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ecommercenested
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// UserRegistry keeps a bounded ring of recently-generated user ids together
+// with the organization they belong to, so that dependent generators (e.g.
+// orderGen) can reference users that actually exist in the stream instead of
+// fabricating ids statistically. It is safe for concurrent readers and
+// writers.
+type UserRegistry struct {
+	mu   sync.Mutex
+	ids  []int64
+	orgs []string
+	next int
+	size int
+	rng  *rand.Rand
+}
+
+// NewUserRegistry creates a registry that remembers at most capacity users,
+// sampling existing ones via rng so that readers don't contend on the
+// global math/rand lock. The draws repeat for the same seed, but which user
+// a draw lands on depends on how many users were registered at that moment.
+func NewUserRegistry(capacity int, rng *rand.Rand) *UserRegistry {
+	return &UserRegistry{
+		ids:  make([]int64, capacity),
+		orgs: make([]string, capacity),
+		rng:  rng,
+	}
+}
+
+// Register records a newly generated user id and its organization name,
+// overwriting the oldest entry once the ring is full.
+func (r *UserRegistry) Register(id int64, org string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cap := len(r.ids)
+	r.ids[r.next] = id
+	r.orgs[r.next] = org
+	r.next = (r.next + 1) % cap
+	if r.size < cap {
+		r.size++
+	}
+}
+
+// SampleExisting returns a random previously-registered user id and its
+// organization name. ok is false if no user has been registered yet.
+func (r *UserRegistry) SampleExisting() (id int64, org string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size == 0 {
+		return 0, "", false
+	}
+	i := r.rng.Intn(r.size)
+	return r.ids[i], r.orgs[i], true
+}
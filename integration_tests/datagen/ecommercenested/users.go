@@ -6,115 +6,261 @@ import (
 	"datagen/sink"
 	"fmt"
 	"math/rand"
-	"sync/atomic"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 )
 
+// organization is the pure domain representation of an org. gen.Populate
+// fills it from the fake tags below; its wire shape is organizationDTO.
 type organization struct {
+	Name            string  `fake:"{company}"`
+	Address         address `fake:"skip"`
+	IsOutOfBusiness string  `fake:"bool_str:10"` // String on purpose. Cast it to bool in SQL
+	Industry        string  `fake:"enum:technology,healthcare,finance,education,retail,entertainment,automotive,energy,hospitality,telecommunications,real estate,agriculture,construction,fashion,media,pharmaceuticals,aviation,sports,logistics,consulting"`
+}
+
+// organizationDTO is the JSON shape an organization is serialized to on the wire.
+type organizationDTO struct {
 	Name            string  `json:"name"`
 	Address         address `json:"address"`
-	Industry        string  `json:"industry"`           // TODO: write generator for that
 	IsOutOfBusiness string  `json:"is_out_of_business"` // String on purpose. Cast it to bool in SQL
+	Industry        string  `json:"industry"`
+}
+
+func (o organization) toDTO() organizationDTO {
+	return organizationDTO{
+		Name:            o.Name,
+		Address:         o.Address,
+		IsOutOfBusiness: o.IsOutOfBusiness,
+		Industry:        o.Industry,
+	}
 }
 
+// organizationUserRelation is the pure domain representation of a user's
+// relation to their org; its wire shape is organizationUserRelationDTO.
 type organizationUserRelation struct {
-	Role string
+	Role string `fake:"enum:developer,sales representative,customer support agent,human resources specialist,marketing coordinator,financial analyst,project manager,data scientist,operations coordinator,quality assurance tester"`
 }
 
-type userEvent struct {
-	sink.BaseSinkRecord
-	Id             int64                    `json:"user_event_id"`
-	UserName       string                   `json:"user_name"`
-	EventTimestamp string                   `json:"event_timestamp"`
-	Org            organization             `json:"organization"`
-	OrgRelation    organizationUserRelation `json:"organization_user_relation"`
-	Email          string                   `json:"email"`
+// organizationUserRelationDTO is the JSON shape an organizationUserRelation
+// is serialized to on the wire. No json tag on Role, matching the field's
+// pre-existing wire name.
+type organizationUserRelationDTO struct {
+	Role string
 }
 
-func (r userEvent) Key() string {
-	return fmt.Sprint(r.Id)
+func (r organizationUserRelation) toDTO() organizationUserRelationDTO {
+	return organizationUserRelationDTO{Role: r.Role}
 }
 
-func getRandRole() string {
-	roles := []string{"developer", "sales representative", "customer support agent", "human resources specialist", "marketing coordinator", "financial analyst", "project manager", "data scientist", "operations coordinator", "quality assurance tester"}
-	return roles[rand.Intn(len(roles))]
+// User is the pure domain representation of a user event: no JSON tags, no
+// sink metadata. Org.IsOutOfBusiness still holds the wire's "True"/"False"
+// string rather than a bool. gen.Populate fills it directly from the fake
+// tags below.
+type User struct {
+	Id             int64  `fake:"skip"`
+	UserName       string `fake:"{username}"`
+	EventTimestamp string `fake:"skip"`
+	Org            organization
+	OrgRelation    organizationUserRelation
+	Email          string `fake:"skip"`
 }
 
-func getRandIndustry() string {
-	i := []string{"technology", "healthcare", "finance", "education", "retail", "entertainment", "automotive", "energy", "hospitality", "telecommunications", "real estate", "agriculture", "construction", "fashion", "media", "pharmaceuticals", "aviation", "sports", "logistics", "consulting"}
-	return i[rand.Intn(len(i))]
+// UserEventDTO is the JSON shape a User is serialized to on the wire.
+type UserEventDTO struct {
+	Id             int64                       `json:"user_event_id"`
+	UserName       string                      `json:"user_name"`
+	EventTimestamp string                      `json:"event_timestamp"`
+	Org            organizationDTO             `json:"organization"`
+	OrgRelation    organizationUserRelationDTO `json:"organization_user_relation"`
+	Email          string                      `json:"email"`
 }
 
-// likelihood in percentage
-func getRandIsOutOfBusiness(likelihood uint) string {
-	if rand.Intn(100) < int(likelihood) {
-		return "True"
+func (u User) toDTO() UserEventDTO {
+	return UserEventDTO{
+		Id:             u.Id,
+		UserName:       u.UserName,
+		EventTimestamp: u.EventTimestamp,
+		Org:            u.Org.toDTO(),
+		OrgRelation:    u.OrgRelation.toDTO(),
+		Email:          u.Email,
 	}
-	return "False"
 }
 
-// I want to merge users and orders. orderEvents should reflect user IDs
+func (d UserEventDTO) Key() string {
+	return fmt.Sprint(d.Id)
+}
+
+// users and orders are merged through registry: orderGen samples real user
+// ids/orgs from it so nested order events reference users that actually
+// exist in the stream.
+
+const defaultPoolSize = 1000
+
+// UserGenOption configures a userGen returned by NewUserGen.
+type UserGenOption func(*userGen)
+
+// WithPoolSize sets the capacity of the reservoir used to buffer generated
+// events before they are drained to outCh. Defaults to defaultPoolSize.
+func WithPoolSize(n int) UserGenOption {
+	return func(g *userGen) { g.poolSize = n }
+}
+
+// WithSamplingMode selects how the pool decides which events to retain once
+// it is full. Defaults to SamplingModeUniform.
+func WithSamplingMode(mode SamplingMode) UserGenOption {
+	return func(g *userGen) { g.samplingMode = mode }
+}
+
+// WithRNG gives the generator its own source of randomness instead of the
+// global math/rand, so a generator seeded the same way produces the same
+// sequence of users and parallel generators don't contend on the global
+// lock. EventTimestamp comes from the clock (see WithClock), and which
+// pooled events reach outCh depends on timing, so this alone does not make
+// output byte-identical.
+func WithRNG(rng *rand.Rand) UserGenOption {
+	return func(g *userGen) { g.rng = rng }
+}
+
+// WithClock overrides how the generator reads "now", so CI and benchmark
+// harnesses can drive EventTimestamp without depending on wall-clock drift.
+func WithClock(clock func() time.Time) UserGenOption {
+	return func(g *userGen) { g.clock = clock }
+}
+
+// defaultGenInterval paces the background generation loop in Load so a
+// slow or stalled sink doesn't spin a CPU core just to keep evicting the
+// pool. Each tick produces one batch.
+const defaultGenInterval = time.Millisecond
+
+// WithGenInterval overrides the pacing between generated batches in Load's
+// background producer. Defaults to defaultGenInterval.
+func WithGenInterval(d time.Duration) UserGenOption {
+	return func(g *userGen) { g.genInterval = d }
+}
 
 type userGen struct {
-	bankruptLikelihood int // likelihood in percentage that an organization is out of business
-	seqUserId          int64
-	faker              *gofakeit.Faker
-	maxUserId          *atomic.Pointer[int64]
+	seqUserId    int64
+	faker        *gofakeit.Faker
+	registry     *UserRegistry
+	poolSize     int
+	samplingMode SamplingMode
+	pool         *eventPool
+	rng          *rand.Rand
+	clock        func() time.Time
+	genInterval  time.Duration
 }
 
-func NewUserGen(maxId *atomic.Pointer[int64]) *userGen {
+func NewUserGen(registry *UserRegistry, opts ...UserGenOption) *userGen {
 	// TODO: I may need to add number of items here?
-	return &userGen{
-		bankruptLikelihood: 10,
-		seqUserId:          0,
-		maxUserId:          maxId,
+	g := &userGen{
+		seqUserId:    0,
+		registry:     registry,
+		poolSize:     defaultPoolSize,
+		samplingMode: SamplingModeUniform,
+		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		clock:        time.Now,
+		genInterval:  defaultGenInterval,
+	}
+	for _, opt := range opts {
+		opt(g)
 	}
+	g.pool = newEventPool(g.poolSize, g.rng)
+	g.faker = gen.NewFaker(g.rng)
+	return g
+}
+
+// NumSeen, NumSaved and NumDropped report the pool's lifetime counters so
+// operators can tell whether Load is dropping events under backpressure.
+func (g *userGen) NumSeen() int64 {
+	seen, _, _ := g.pool.stats()
+	return seen
 }
 
-func (g *userGen) getUserEvent() userEvent {
+func (g *userGen) NumSaved() int64 {
+	_, saved, _ := g.pool.stats()
+	return saved
+}
+
+func (g *userGen) NumDropped() int64 {
+	_, _, dropped := g.pool.stats()
+	return dropped
+}
+
+func (g *userGen) getUser() User {
 	g.seqUserId++
-	g.maxUserId.Store(&g.seqUserId)
-	// TODO: seqUserId and maxUserId should be the same
-	// Need custom atomic Inc function for that
-
-	orgName := g.faker.Company()
-	org := organization{
-		Name:            orgName,
-		Address:         getAddress(g.faker),
-		Industry:        getRandIndustry(),
-		IsOutOfBusiness: getRandIsOutOfBusiness(uint(g.bankruptLikelihood)),
-	}
 
-	name := g.faker.Username()
-	return userEvent{
-		Id:             g.seqUserId,
-		UserName:       name,
-		EventTimestamp: time.Now().Format(gen.RwTimestampNaiveLayout),
-		Org:            org,
-		OrgRelation:    organizationUserRelation{Role: getRandRole()},
-		Email:          fmt.Sprintf("%s@%s.com", name, orgName),
+	var user User
+	if err := gen.Populate(g.faker, g.rng, &user); err != nil {
+		panic(err) // fake tags are static; a failure here is a programmer error
 	}
+	user.Org.Address = getAddress(g.faker)
+	user.Id = g.seqUserId
+	user.EventTimestamp = g.clock().Format(gen.RwTimestampNaiveLayout)
+	user.Email = fmt.Sprintf("%s@%s.com", user.UserName, user.Org.Name)
+
+	g.registry.Register(user.Id, user.Org.Name)
+	return user
 }
 
 func (g *userGen) generate() []sink.SinkRecord {
 	var records []sink.SinkRecord
 	for i := 0; i < 100; i++ {
-		records = append(records, g.getUserEvent())
+		dto := g.getUser().toDTO()
+		records = append(records, sink.Envelope{DTO: dto, Topic: "user_events"})
 	}
 	return records
 }
 
-// implement a load function
+// Load generates events into a bounded reservoir and drains it to outCh in
+// the background, so a slow sink causes events to be sampled out instead of
+// stalling generation. In SamplingModeFIFO it falls back to the original
+// behavior of sending every event directly, blocking on outCh.
 func (g *userGen) Load(ctx context.Context, outCh chan<- sink.SinkRecord) {
-	for {
-		records := g.generate()
-		for _, record := range records {
+	if g.samplingMode == SamplingModeFIFO {
+		for {
+			records := g.generate()
+			for _, record := range records {
+				select {
+				case <-ctx.Done():
+					return
+				case outCh <- record:
+				}
+			}
+		}
+	}
+
+	go func() {
+		genTicker := time.NewTicker(g.genInterval)
+		defer genTicker.Stop()
+		for {
 			select {
 			case <-ctx.Done():
 				return
-			case outCh <- record:
+			case <-genTicker.C:
+				for _, record := range g.generate() {
+					g.pool.offer(record)
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, record := range g.pool.drain() {
+				select {
+				case outCh <- record:
+					g.pool.markDelivered(true)
+				default:
+					// outCh is still full; drop rather than block the drain loop
+					g.pool.markDelivered(false)
+				}
 			}
 		}
 	}
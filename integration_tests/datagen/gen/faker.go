@@ -0,0 +1,216 @@
+package gen
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// NewFaker returns a *gofakeit.Faker seeded from rng, so every generator
+// constructs its faker the same way: after rng is finalized (e.g. by a
+// WithRNG-style option), not before. Building a faker from a different rng,
+// or before options have had a chance to replace it, is the historical bug
+// this centralizes away.
+func NewFaker(rng *rand.Rand) *gofakeit.Faker {
+	return gofakeit.New(rng.Int63())
+}
+
+// maxDepth caps how deep Populate will recurse into nested structs, so a
+// self-referential or deeply nested type cannot blow the stack or allocate
+// without bound.
+const maxDepth = 8
+
+type fieldKind int
+
+const (
+	kindSkip fieldKind = iota
+	kindTemplate
+	kindEnum
+	kindBoolStr
+	kindStruct
+	kindSlice
+)
+
+// fieldPlan is the pre-computed, per-field instruction for populating a
+// struct so the hot path does no tag parsing or reflection lookup.
+type fieldPlan struct {
+	index      int
+	name       string
+	kind       fieldKind
+	template   string   // kindTemplate: the {..} gofakeit format string
+	enum       []string // kindEnum: candidate values
+	likelihood int      // kindBoolStr: percentage chance of "True"
+	minLen     int      // kindSlice
+	maxLen     int      // kindSlice
+}
+
+// typePlan is the cached, per-struct-type populate plan.
+type typePlan struct {
+	fields []fieldPlan
+}
+
+var planCache sync.Map // reflect.Type -> *typePlan
+
+// Populate fills the exported fields of the struct pointed to by target
+// according to its `fake` struct tags, using faker for gofakeit templates
+// and rng for everything else (enum picks, bool_str rolls, slice lengths),
+// so callers get byte-identical output across runs when both are seeded
+// the same way:
+//
+//	fake:"{username}"        gofakeit template, see gofakeit.Generate
+//	fake:"enum:a,b,c"        uniform pick among the comma-separated values
+//	fake:"bool_str:10"       "True"/"False" string with the given percent likelihood
+//	fake:"len:3"/"len:2-5"   required on slices; fixed or ranged length
+//	fake:"skip"              leave the field untouched
+//
+// Fields with no fake tag are left untouched if scalar, or recursed into if
+// they are a nested struct, so callers can still assemble cross-field values
+// (like an email derived from a username) by hand after calling Populate.
+// An unrecognized tag is an error. Slice fields are never expanded without
+// an explicit len tag: an untagged or skip-tagged slice is left untouched
+// and any other tag on a slice is an error, to avoid the classic
+// reflection-faker blowup where nested min/max sizes compound into
+// unbounded memory use.
+func Populate(faker *gofakeit.Faker, rng *rand.Rand, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("gen: Populate requires a pointer to a struct, got %T", target)
+	}
+	return populateValue(faker, rng, v.Elem(), 0)
+}
+
+func populateValue(faker *gofakeit.Faker, rng *rand.Rand, v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("gen: Populate exceeded max recursion depth %d at %s", maxDepth, v.Type())
+	}
+	plan, err := planFor(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range plan.fields {
+		if err := applyField(faker, rng, v.Field(f.index), f, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyField(faker *gofakeit.Faker, rng *rand.Rand, fv reflect.Value, f fieldPlan, depth int) error {
+	switch f.kind {
+	case kindSkip:
+	case kindTemplate:
+		fv.SetString(faker.Generate(f.template))
+	case kindEnum:
+		fv.SetString(f.enum[rng.Intn(len(f.enum))])
+	case kindBoolStr:
+		if rng.Intn(100) < f.likelihood {
+			fv.SetString("True")
+		} else {
+			fv.SetString("False")
+		}
+	case kindStruct:
+		return populateValue(faker, rng, fv, depth+1)
+	case kindSlice:
+		n := f.minLen
+		if f.maxLen > f.minLen {
+			n += rng.Intn(f.maxLen - f.minLen + 1)
+		}
+		slice := reflect.MakeSlice(fv.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if elem := slice.Index(i); elem.Kind() == reflect.Struct {
+				if err := populateValue(faker, rng, elem, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		fv.Set(slice)
+	}
+	return nil
+}
+
+// planFor returns the cached populate plan for t, computing and storing it
+// on first use so the hot path never re-parses tags.
+func planFor(t reflect.Type) (*typePlan, error) {
+	if cached, ok := planCache.Load(t); ok {
+		return cached.(*typePlan), nil
+	}
+
+	plan := &typePlan{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+
+		fp := fieldPlan{index: i, name: sf.Name}
+		tag, ok := sf.Tag.Lookup("fake")
+		switch {
+		case !ok:
+			// Unannotated fields are left for the caller to set, except
+			// nested structs, which we still walk so a struct made only of
+			// tagged sub-structs doesn't need tags of its own.
+			if sf.Type.Kind() == reflect.Struct {
+				fp.kind = kindStruct
+			} else {
+				fp.kind = kindSkip
+			}
+		case tag == "skip":
+			fp.kind = kindSkip
+		case strings.HasPrefix(tag, "{"):
+			fp.kind = kindTemplate
+			fp.template = tag
+		case strings.HasPrefix(tag, "enum:"):
+			fp.kind = kindEnum
+			fp.enum = strings.Split(strings.TrimPrefix(tag, "enum:"), ",")
+		case strings.HasPrefix(tag, "bool_str:"):
+			likelihood, err := strconv.Atoi(strings.TrimPrefix(tag, "bool_str:"))
+			if err != nil {
+				return nil, fmt.Errorf("gen: invalid bool_str tag %q on %s.%s: %w", tag, t, sf.Name, err)
+			}
+			fp.kind = kindBoolStr
+			fp.likelihood = likelihood
+		case strings.HasPrefix(tag, "len:"):
+			if sf.Type.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("gen: len tag only valid on slice fields, got %s.%s", t, sf.Name)
+			}
+			minLen, maxLen, err := parseLen(strings.TrimPrefix(tag, "len:"))
+			if err != nil {
+				return nil, fmt.Errorf("gen: invalid len tag %q on %s.%s: %w", tag, t, sf.Name, err)
+			}
+			fp.kind = kindSlice
+			fp.minLen, fp.maxLen = minLen, maxLen
+		default:
+			return nil, fmt.Errorf("gen: unrecognized fake tag %q on %s.%s", tag, t, sf.Name)
+		}
+
+		if sf.Type.Kind() == reflect.Slice && fp.kind != kindSlice && fp.kind != kindSkip {
+			return nil, fmt.Errorf("gen: slice field %s.%s needs an explicit len tag, refusing to auto-expand", t, sf.Name)
+		}
+
+		plan.fields = append(plan.fields, fp)
+	}
+
+	planCache.Store(t, plan)
+	return plan, nil
+}
+
+func parseLen(spec string) (min, max int, err error) {
+	parts := strings.SplitN(spec, "-", 2)
+	min, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 1 {
+		return min, min, nil
+	}
+	max, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return min, max, nil
+}
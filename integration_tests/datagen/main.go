@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"datagen/ecommercenested"
+	"datagen/sink"
+	"flag"
+	"log"
+	"math/rand"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed for the generators' and the registry's random number sources")
+	flag.Parse()
+
+	// Every generator and the registry they share get their own *rand.Rand
+	// derived from the single top-level seed, so each one repeats the same
+	// sequence of random draws for the same --seed and generators never
+	// contend on the global math/rand lock. Output is not byte-identical
+	// across runs: no WithClock is passed, so timestamps come from the wall
+	// clock, and which events reach outCh, in what order, and which users an
+	// order samples all depend on goroutine scheduling.
+	root := rand.New(rand.NewSource(*seed))
+	registry := ecommercenested.NewUserRegistry(1000, rand.New(rand.NewSource(root.Int63())))
+
+	userGen := ecommercenested.NewUserGen(registry,
+		ecommercenested.WithRNG(rand.New(rand.NewSource(root.Int63()))))
+	orderGen := ecommercenested.NewOrderGen(registry,
+		ecommercenested.WithOrderRNG(rand.New(rand.NewSource(root.Int63()))))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	outCh := make(chan sink.SinkRecord)
+	go userGen.Load(ctx, outCh)
+	go orderGen.Load(ctx, outCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case record := <-outCh:
+			log.Println(record.Key())
+		}
+	}
+}
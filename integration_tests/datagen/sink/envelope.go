@@ -0,0 +1,23 @@
+package sink
+
+// DTO is the JSON-serializable wire shape a generator produces for a single
+// domain record.
+type DTO interface {
+	Key() string
+}
+
+// Envelope pairs a DTO with the delivery metadata a sink backend needs
+// (key, topic, partition), instead of that metadata being embedded
+// directly into the domain or DTO struct. This lets a generator swap in a
+// new DTO for the same domain (e.g. an Avro or Protobuf variant) without
+// touching the sink backends, and vice versa.
+type Envelope struct {
+	DTO       DTO
+	Topic     string
+	Partition int32
+}
+
+// Key returns the underlying DTO's key, so Envelope satisfies SinkRecord.
+func (e Envelope) Key() string {
+	return e.DTO.Key()
+}